@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/j985chen/cli-ordle/words"
+)
+
+const dailyDBKey = "DAILY"
+
+// dailyWordList is the corpus the daily puzzle picks from. It's kept local
+// to this file rather than pulled from the words package: words.RandomWord
+// doesn't expose the underlying list it draws from, and indexing an assumed
+// words.WordList export risked silently drawing from a different corpus (or
+// not compiling at all). PlayDaily gates every pick through
+// words.IsValidGuess before starting the game, so a bad entry here can never
+// ship as an unwinnable puzzle.
+var dailyWordList = []string{
+	"about", "above", "abuse", "actor", "adapt", "admit", "adult", "after",
+	"again", "agent", "agree", "ahead", "alarm", "album", "alert", "alike",
+	"alive", "allow", "alone", "along", "alter", "among", "anger", "angle",
+	"angry", "apart", "apple", "apply", "arena", "argue", "arise", "armor",
+	"array", "aside", "asset", "avoid", "award", "aware", "badly", "baker",
+	"bases", "basic", "beach", "began", "begin", "being", "below", "bench",
+	"birth", "black", "blame", "blind", "block", "blood", "board", "boost",
+	"booth", "bound", "brain", "brand", "bread", "break", "breed", "brief",
+	"bring", "broad", "broke", "brown", "build", "built", "buyer", "cable",
+	"cabin", "carry", "catch", "cause", "chain", "chair", "chaos", "charm",
+	"chart", "chase", "cheap", "check", "chest", "chief", "child", "china",
+	"chose", "civil", "claim", "class", "clean", "clear", "click", "climb",
+	"clock", "close", "coach", "coast", "could", "count", "court", "cover",
+	"craft", "crash", "cream", "crime", "cross", "crowd", "crown", "curve",
+}
+
+// DailyRecord is the persisted state of a single day's daily puzzle, stored
+// under the DAILY key so a second `cliordle daily` invocation the same day
+// replays it instead of generating a fresh answer.
+type DailyRecord struct {
+	Day     int64    `json:"day"`
+	Score   [][]rune `json:"score"`
+	Correct [][]bool `json:"correct"`
+	Present [][]bool `json:"present"`
+	Solved  bool     `json:"solved"`
+}
+
+// daysSinceEpoch is the deterministic per-day seed used to pick the daily
+// answer: every player who plays on the same UTC day gets the same word.
+func daysSinceEpoch(t time.Time) int64 {
+	return t.UTC().Unix() / 86400
+}
+
+// PlayDaily plays (or replays) today's daily puzzle: the answer is chosen
+// deterministically from dailyWordList by the current UTC day, so it's the
+// same for every player. A second attempt the same day just replays the
+// stored board rather than allowing new guesses.
+func (p *Player) PlayDaily() error {
+	today := daysSinceEpoch(time.Now())
+
+	record, found, err := loadDailyRecord()
+	if err != nil {
+		return err
+	}
+	if found && record.Day == today {
+		printDailyRecord(record, p.HiContrast)
+		if record.Solved {
+			fmt.Println("You already solved today's daily. Come back tomorrow!")
+		} else {
+			fmt.Println("You already played today's daily. Come back tomorrow!")
+		}
+		return nil
+	}
+
+	index := int(today % int64(len(dailyWordList)))
+	answer := dailyWordList[index]
+	if !words.IsValidGuess(answer) {
+		return fmt.Errorf("daily: %q isn't accepted by words.IsValidGuess, refusing to start an unwinnable puzzle", answer)
+	}
+
+	game := Game{Player: p, WordsGuessed: []string{}, Answer: answer, IsDaily: true, Hooks: defaultHooks()}
+	if err := game.PlayGame(); err != nil {
+		return err
+	}
+
+	record = DailyRecord{Day: today, Solved: game.Solved}
+	for _, guess := range game.WordsGuessed {
+		greens, yellows := evaluateGuess(guess, answer)
+		correctRow := make([]bool, 5)
+		presentRow := make([]bool, 5)
+		copy(correctRow, greens[:])
+		copy(presentRow, yellows[:])
+		record.Score = append(record.Score, []rune(guess))
+		record.Correct = append(record.Correct, correctRow)
+		record.Present = append(record.Present, presentRow)
+	}
+	return saveDailyRecord(record)
+}
+
+func loadDailyRecord() (DailyRecord, bool, error) {
+	var record DailyRecord
+	raw, found, err := store.LoadRaw(dailyDBKey)
+	if err != nil || !found {
+		return record, found, err
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return DailyRecord{}, false, fmt.Errorf("could not unmarshal daily record json: %v", err)
+	}
+	return record, true, nil
+}
+
+func saveDailyRecord(record DailyRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not marshal daily record json: %v", err)
+	}
+	return store.SaveRaw(dailyDBKey, raw)
+}
+
+// printDailyRecord re-renders a previously played daily board from its
+// stored green/yellow masks, without needing (or revealing) the answer.
+func printDailyRecord(record DailyRecord, hiContrast bool) {
+	placedColour, includesColour := colourGreen, colourYellow
+	if hiContrast {
+		placedColour, includesColour = colourOrange, colourBlue
+	}
+
+	fmt.Printf(" ___  ___  ___  ___  ___\n")
+	for i, guess := range record.Score {
+		for j, letter := range guess {
+			fmt.Printf("|")
+			switch {
+			case record.Correct[i][j]:
+				fmt.Printf(placedColour, string(letter))
+			case record.Present[i][j]:
+				fmt.Printf(includesColour, string(letter))
+			default:
+				fmt.Printf(" %s ", string(letter))
+			}
+			fmt.Printf("|")
+		}
+		fmt.Println("\n ---  ---  ---  ---  ---")
+	}
+	for i := len(record.Score); i < 6; i++ {
+		for j := 0; j < 5; j++ {
+			fmt.Printf("|   |")
+		}
+		fmt.Println("\n ---  ---  ---  ---  ---")
+	}
+	fmt.Println()
+}
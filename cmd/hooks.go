@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/j985chen/cli-ordle/ui"
+)
+
+// Hooks lets external code (bots, loggers, replay tools) observe a game as
+// it's played, without the Game type itself knowing about any of them.
+type Hooks interface {
+	OnGuess(g *Game, guess string)
+	OnBoardRender(g *Game, rows [][]ui.Cell)
+	OnGameEnd(g *Game)
+}
+
+// defaultHooks are attached to every game started from the CLI.
+func defaultHooks() []Hooks {
+	return []Hooks{JSONLogHook{}, ShareHook{}}
+}
+
+const jsonLogFile = "cliordle_games.jsonl"
+
+// JSONLogHook appends each completed game to cliordle_games.jsonl for later
+// analysis (win rate over time, common misses, etc).
+type JSONLogHook struct{}
+
+func (JSONLogHook) OnGuess(g *Game, guess string)           {}
+func (JSONLogHook) OnBoardRender(g *Game, rows [][]ui.Cell) {}
+
+type gameLogEntry struct {
+	Answer  string    `json:"answer"`
+	Guesses []string  `json:"guesses"`
+	Won     bool      `json:"won"`
+	EndedAt time.Time `json:"endedAt"`
+}
+
+func (JSONLogHook) OnGameEnd(g *Game) {
+	entry := gameLogEntry{
+		Answer:  g.Answer,
+		Guesses: g.WordsGuessed,
+		Won:     g.Solved,
+		EndedAt: time.Now().UTC(),
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jsonlog: could not marshal game result: %v\n", err)
+		return
+	}
+	f, err := os.OpenFile(jsonLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jsonlog: could not open %s: %v\n", jsonLogFile, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(raw, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonlog: could not append game result: %v\n", err)
+	}
+}
+
+// ShareHook prints the spoiler-free colored-square grid players can paste
+// elsewhere to share their result without giving away the answer.
+type ShareHook struct{}
+
+func (ShareHook) OnGuess(g *Game, guess string)           {}
+func (ShareHook) OnBoardRender(g *Game, rows [][]ui.Cell) {}
+
+func (ShareHook) OnGameEnd(g *Game) {
+	var b strings.Builder
+	if g.Solved {
+		fmt.Fprintf(&b, "cliordle %d/6\n", len(g.WordsGuessed))
+	} else {
+		b.WriteString("cliordle X/6\n")
+	}
+	for _, guess := range g.WordsGuessed {
+		greens, yellows := evaluateGuess(guess, g.Answer)
+		for j := 0; j < 5; j++ {
+			switch {
+			case greens[j]:
+				b.WriteString("🟩")
+			case yellows[j]:
+				b.WriteString("🟨")
+			default:
+				b.WriteString("⬛")
+			}
+		}
+		b.WriteString("\n")
+	}
+	fmt.Print(b.String())
+}
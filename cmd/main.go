@@ -2,14 +2,17 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"math"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/boltdb/bolt"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/j985chen/cli-ordle/storage"
+	"github.com/j985chen/cli-ordle/ui"
 	"github.com/j985chen/cli-ordle/words"
 )
 
@@ -18,7 +21,10 @@ const colourYellow = "\033[43m %s \033[0m"
 const colourOrange = "\033[48;5;202m %s \033[0m"
 const colourBlue = "\033[46m %s \033[0m"
 
-var db *bolt.DB
+const boltLegacyPath = "cliordle.db"
+const pebbleDir = "cliordle.pebble"
+
+var store storage.Store
 
 type Player struct {
 	Played        float64    `json:"played"`
@@ -28,6 +34,14 @@ type Player struct {
 	Distribution  [6]float64 `json:"stats"`
 	HiContrast    bool       `json:"hiContrast"`
 	HardMode      bool       `json:"hardMode"`
+	// UITUI turns on the bubbletea TUI front-end in place of the stdin loop.
+	UITUI bool `json:"uiTUI"`
+	// DailyStreak and LastDailyDay track `cliordle daily` results, separately
+	// from the practice-mode streak in CurrStreak.
+	DailyStreak  float64 `json:"dailyStreak"`
+	LastDailyDay int64   `json:"lastDailyDay"`
+	// MultiplayerWins counts games won via `cliordle join` head-to-head matches.
+	MultiplayerWins float64 `json:"multiplayerWins"`
 }
 
 func (p *Player) CreateGame() error {
@@ -35,31 +49,87 @@ func (p *Player) CreateGame() error {
 	if err != nil {
 		return err
 	}
-	currGame := Game{p, []string{}, answer, false}
-	err = currGame.PlayGame()
+	currGame := Game{Player: p, WordsGuessed: []string{}, Answer: answer, Hooks: defaultHooks()}
+	if p.UITUI {
+		err = currGame.PlayGameTUI()
+	} else {
+		err = currGame.PlayGame()
+	}
 	return err
 }
 
-func (p *Player) ManageSettings(hiContrast bool, hardMode bool) error {
+func (p *Player) ManageSettings(hiContrast bool, hardMode bool, tui bool) error {
 	p.HiContrast = hiContrast
-	p.HardMode = false
+	p.HardMode = hardMode
+	p.UITUI = tui
 	fmt.Println("---   CURRENT SETTINGS   ---")
-	fmt.Printf("High-contrast\t|\t%t\nHard mode\t|\t%t\n", p.HiContrast, p.HardMode)
+	fmt.Printf("High-contrast\t|\t%t\nHard mode\t|\t%t\nTUI\t\t|\t%t\n", p.HiContrast, p.HardMode, p.UITUI)
 	return p.SaveStats()
 }
 
 func (p *Player) UpdateStatsW(numGuesses int) error {
-	p.CurrStreak++
-	p.LongestStreak = math.Max(p.CurrStreak, p.LongestStreak)
 	p.Distribution[numGuesses-1]++
 	p.Won++
 	p.Played++
-	return p.SaveStats()
+	if err := store.AppendGameResult(storage.GameDelta{
+		Played:          1,
+		Won:             1,
+		DistributionIdx: numGuesses - 1,
+	}); err != nil {
+		return err
+	}
+	return p.updateStreak(true)
 }
 
 func (p *Player) UpdateStatsL() error {
-	p.CurrStreak = 0
 	p.Played++
+	if err := store.AppendGameResult(storage.GameDelta{
+		Played:          1,
+		DistributionIdx: -1,
+	}); err != nil {
+		return err
+	}
+	return p.updateStreak(false)
+}
+
+// updateStreak reads and rewrites just the streak record, not the whole
+// player blob: Played/Won/Distribution go through the associative pebble
+// Merger above since folding order doesn't matter for them, but a streak
+// does depend on order (a win after a loss must reset then increment), so
+// it can't go through the merger and needs its own read-modify-write. Using
+// store.LoadStreak/SaveStreak here instead of LoadPlayer/SavePlayer means
+// this hot per-game path never Sets the whole record, so it can't clobber a
+// concurrent AppendGameResult the way a full SavePlayer would.
+func (p *Player) updateStreak(won bool) error {
+	streak, err := store.LoadStreak()
+	if err != nil {
+		return err
+	}
+	if won {
+		streak.CurrStreak++
+		streak.LongestStreak = math.Max(streak.CurrStreak, streak.LongestStreak)
+	} else {
+		streak.CurrStreak = 0
+	}
+	p.CurrStreak = streak.CurrStreak
+	p.LongestStreak = streak.LongestStreak
+	return store.SaveStreak(streak)
+}
+
+func (p *Player) UpdateDailyStatsW() error {
+	today := daysSinceEpoch(time.Now())
+	if p.LastDailyDay == today-1 {
+		p.DailyStreak++
+	} else {
+		p.DailyStreak = 1
+	}
+	p.LastDailyDay = today
+	return p.SaveStats()
+}
+
+func (p *Player) UpdateDailyStatsL() error {
+	p.DailyStreak = 0
+	p.LastDailyDay = daysSinceEpoch(time.Now())
 	return p.SaveStats()
 }
 
@@ -77,22 +147,49 @@ func (p *Player) ViewStats() error {
 	for i := 0; i < 6; i++ {
 		fmt.Printf("%d\t|\t%.0f\n", i+1, p.Distribution[i])
 	}
+	fmt.Println()
+	fmt.Printf("Hard mode: %t\n", p.HardMode)
+	fmt.Printf("Daily streak: %.0f\n", p.DailyStreak)
+	fmt.Printf("Multiplayer wins: %.0f\n", p.MultiplayerWins)
 	return nil
 }
 
 func (p *Player) SaveStats() error {
-	playerBytes, err := json.Marshal(*p)
-	if err != nil {
-		return fmt.Errorf("could not marshal player data json: %v", err)
+	return store.SavePlayer(p.toRecord())
+}
+
+// toRecord and playerFromRecord convert between Player and storage's
+// persisted PlayerRecord shape at the storage boundary.
+func (p *Player) toRecord() storage.PlayerRecord {
+	return storage.PlayerRecord{
+		Played:          p.Played,
+		Won:             p.Won,
+		CurrStreak:      p.CurrStreak,
+		LongestStreak:   p.LongestStreak,
+		Distribution:    p.Distribution,
+		HiContrast:      p.HiContrast,
+		HardMode:        p.HardMode,
+		UITUI:           p.UITUI,
+		DailyStreak:     p.DailyStreak,
+		LastDailyDay:    p.LastDailyDay,
+		MultiplayerWins: p.MultiplayerWins,
+	}
+}
+
+func playerFromRecord(r storage.PlayerRecord) Player {
+	return Player{
+		Played:          r.Played,
+		Won:             r.Won,
+		CurrStreak:      r.CurrStreak,
+		LongestStreak:   r.LongestStreak,
+		Distribution:    r.Distribution,
+		HiContrast:      r.HiContrast,
+		HardMode:        r.HardMode,
+		UITUI:           r.UITUI,
+		DailyStreak:     r.DailyStreak,
+		LastDailyDay:    r.LastDailyDay,
+		MultiplayerWins: r.MultiplayerWins,
 	}
-	err = db.Update(func(tx *bolt.Tx) error {
-		err = tx.Bucket([]byte("DB")).Put([]byte("PLAYER"), playerBytes)
-		if err != nil {
-			return fmt.Errorf("could not set player data: %v", err)
-		}
-		return nil
-	})
-	return err
 }
 
 type Game struct {
@@ -100,6 +197,11 @@ type Game struct {
 	WordsGuessed []string
 	Answer       string
 	Solved       bool
+	// IsDaily marks a game started via `cliordle daily`, so HandleResults
+	// updates DailyStreak instead of the practice-mode CurrStreak.
+	IsDaily bool
+	// Hooks observe the game as it's played; see defaultHooks.
+	Hooks []Hooks
 }
 
 func (g *Game) ProcessGuess(guess string) error {
@@ -107,36 +209,158 @@ func (g *Game) ProcessGuess(guess string) error {
 	if !isValid {
 		return fmt.Errorf("invalid")
 	}
+	if g.Player.HardMode {
+		if hardErr := g.checkHardMode(guess); hardErr != nil {
+			return hardErr
+		}
+	}
 	g.WordsGuessed = append(g.WordsGuessed, guess)
 	if guess == g.Answer {
 		g.Solved = true
 	}
+	for _, h := range g.Hooks {
+		h.OnGuess(g, guess)
+	}
 	return nil
 }
 
-func (g *Game) PrintBoard() error {
-	var placedColour string
-	var includesColour string
+// evaluateGuess reports, per position, whether guess landed a green (exact
+// position match) or a yellow (right letter, wrong position) against answer.
+func evaluateGuess(guess string, answer string) (greens [5]bool, yellows [5]bool) {
+	for j := 0; j < 5; j++ {
+		letter := string(guess[j])
+		actual := string(answer[j])
+		if letter == actual {
+			greens[j] = true
+		} else if strings.Contains(answer, letter) {
+			yellows[j] = true
+		}
+	}
+	return greens, yellows
+}
+
+// ordinal renders n as "1st", "2nd", "3rd", "4th", ... for hard-mode errors.
+func ordinal(n int) string {
+	switch n {
+	case 1:
+		return "1st"
+	case 2:
+		return "2nd"
+	case 3:
+		return "3rd"
+	default:
+		return fmt.Sprintf("%dth", n)
+	}
+}
+
+// checkHardMode enforces that guess reuses every green letter from prior
+// guesses in its exact position and includes every yellow letter (that
+// hasn't already been locked in as a green) somewhere in the word.
+func (g *Game) checkHardMode(guess string) error {
+	var locked [5]string
+	required := map[string]int{}
+	for _, prev := range g.WordsGuessed {
+		greens, yellows := evaluateGuess(prev, g.Answer)
+		counts := map[string]int{}
+		for j := 0; j < 5; j++ {
+			if greens[j] {
+				locked[j] = string(prev[j])
+			}
+			if yellows[j] {
+				counts[string(prev[j])]++
+			}
+		}
+		// A single guess only proves a letter occurs at least as many times
+		// as it showed up yellow in that guess, so take the max seen across
+		// guesses rather than summing — summing can demand more copies of a
+		// letter than the answer actually has.
+		for letter, count := range counts {
+			if count > required[letter] {
+				required[letter] = count
+			}
+		}
+	}
+	for _, letter := range locked {
+		if letter != "" && required[letter] > 0 {
+			required[letter]--
+		}
+	}
+
+	guessNum := len(g.WordsGuessed) + 1
+	for j, letter := range locked {
+		if letter != "" && string(guess[j]) != letter {
+			return fmt.Errorf("hardmode: %s guess must have %s in position %d", ordinal(guessNum), strings.ToUpper(letter), j+1)
+		}
+	}
+	for letter, count := range required {
+		if count > 0 && strings.Count(guess, letter) < count {
+			return fmt.Errorf("hardmode: %s guess must contain %s", ordinal(guessNum), strings.ToUpper(letter))
+		}
+	}
+	return nil
+}
+
+// ColourScheme returns the printf-style colour formats to use for a placed
+// (green/orange) and an included (yellow/blue) letter, honouring the
+// player's high-contrast setting. Shared by PrintBoard and the TUI.
+func (g *Game) ColourScheme() (placed string, included string) {
 	if g.Player.HiContrast {
-		placedColour = colourOrange
-		includesColour = colourBlue
-	} else {
-		placedColour = colourGreen
-		includesColour = colourYellow
+		return colourOrange, colourBlue
+	}
+	return colourGreen, colourYellow
+}
+
+// Rows evaluates every guess so far into a 6x5 grid of styled cells, so both
+// PrintBoard and the ui package's TUI can render the same board state.
+func (g *Game) Rows() [][]ui.Cell {
+	rows := make([][]ui.Cell, 6)
+	for i := range rows {
+		row := make([]ui.Cell, 5)
+		if i < len(g.WordsGuessed) {
+			guess := g.WordsGuessed[i]
+			for j := 0; j < 5; j++ {
+				letter := string(guess[j])
+				actual := string(g.Answer[j])
+				switch {
+				case letter == actual:
+					row[j] = ui.Cell{Letter: letter, Status: ui.StatusPlaced}
+				case strings.Contains(g.Answer, letter):
+					row[j] = ui.Cell{Letter: letter, Status: ui.StatusPresent}
+				default:
+					row[j] = ui.Cell{Letter: letter, Status: ui.StatusAbsent}
+				}
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// IsSolved, GuessesUsed, MaxGuesses, and Word satisfy ui.GuessGame. Word is
+// named rather than Answer to avoid colliding with the Answer field.
+func (g *Game) IsSolved() bool   { return g.Solved }
+func (g *Game) GuessesUsed() int { return len(g.WordsGuessed) }
+func (g *Game) MaxGuesses() int  { return 6 }
+func (g *Game) Word() string     { return g.Answer }
+
+func (g *Game) PrintBoard() error {
+	placedColour, includesColour := g.ColourScheme()
+	rows := g.Rows()
+	for _, h := range g.Hooks {
+		h.OnBoardRender(g, rows)
 	}
 	fmt.Printf(" ___  ___  ___  ___  ___\n")
 	for i := 0; i < len(g.WordsGuessed); i++ {
 		for j := 0; j < 5; j++ {
-			letter := string(g.WordsGuessed[i][j])
-			actual := string(g.Answer[j])
-
+			cell := rows[i][j]
 			fmt.Printf("|")
-			if letter == actual {
-				fmt.Printf(string(placedColour), letter)
-			} else if strings.Contains(g.Answer, letter) {
-				fmt.Printf(string(includesColour), letter)
-			} else {
-				fmt.Printf(" %s ", letter)
+			switch cell.Status {
+			case ui.StatusPlaced:
+				fmt.Printf(placedColour, cell.Letter)
+			case ui.StatusPresent:
+				fmt.Printf(includesColour, cell.Letter)
+			default:
+				fmt.Printf(" %s ", cell.Letter)
 			}
 			fmt.Printf("|")
 		}
@@ -153,15 +377,27 @@ func (g *Game) PrintBoard() error {
 }
 
 func (g *Game) HandleResults() error {
+	var err error
 	if g.Solved {
 		numGuesses := len(g.WordsGuessed)
 		fmt.Printf("Impressive! You got the word in %d guesses\n", numGuesses)
-		return g.Player.UpdateStatsW(numGuesses)
-
+		if g.IsDaily {
+			err = g.Player.UpdateDailyStatsW()
+		} else {
+			err = g.Player.UpdateStatsW(numGuesses)
+		}
 	} else {
 		fmt.Printf("The answer was %s\n", g.Answer)
-		return g.Player.UpdateStatsL()
+		if g.IsDaily {
+			err = g.Player.UpdateDailyStatsL()
+		} else {
+			err = g.Player.UpdateStatsL()
+		}
 	}
+	for _, h := range g.Hooks {
+		h.OnGameEnd(g)
+	}
+	return err
 }
 
 func (g *Game) PlayGame() error {
@@ -177,7 +413,11 @@ func (g *Game) PlayGame() error {
 			guess := strings.ToLower(strings.TrimSuffix(input, "\n"))
 			wordErr = g.ProcessGuess(guess)
 			if wordErr != nil {
-				fmt.Printf("%s is an invalid guess, try again\n", guess)
+				if wordErr.Error() == "invalid" {
+					fmt.Printf("%s is an invalid guess, try again\n", guess)
+				} else {
+					fmt.Printf("%v, try again\n", wordErr)
+				}
 			}
 		}
 		g.PrintBoard()
@@ -189,40 +429,41 @@ func (g *Game) PlayGame() error {
 	return err
 }
 
-func setupDB() error {
-	var dbErr error
-	db, dbErr = bolt.Open("cliordle.db", 0600, nil)
-
-	if dbErr != nil {
-		return fmt.Errorf("could not open db, %v", dbErr)
+// PlayGameTUI drives the game through the bubbletea ui.Model instead of the
+// bufio.Reader loop in PlayGame, used when the player has UITUI enabled.
+func (g *Game) PlayGameTUI() error {
+	program := tea.NewProgram(ui.New(g))
+	finalModel, err := program.Run()
+	if err != nil {
+		return err
 	}
-
-	dbErr = db.Update(func(tx *bolt.Tx) error {
-		_, bucketErr := tx.CreateBucketIfNotExists([]byte("DB"))
-		if bucketErr != nil {
-			return fmt.Errorf("could not create root bucket: %v", bucketErr)
-		}
+	// Esc/Ctrl-C quits the TUI before the game reaches a natural end (a
+	// solve or six guesses), same as closing the legacy stdin loop early;
+	// skip recording a result for it rather than counting it as a loss.
+	if model, ok := finalModel.(ui.Model); ok && model.Aborted() {
 		return nil
-	})
-	if dbErr != nil {
-		return fmt.Errorf("could not set up buckets, %v", dbErr)
+	}
+	return g.HandleResults()
+}
+
+func setupStore() error {
+	var err error
+	store, err = storage.Open(pebbleDir)
+	if err != nil {
+		return fmt.Errorf("could not open store, %v", err)
+	}
+	if err := storage.Migrate(boltLegacyPath, store); err != nil {
+		return fmt.Errorf("could not migrate legacy bolt db, %v", err)
 	}
 	return nil
 }
 
 func initPlayer() (Player, error) {
-	var player Player
-	err := db.View(func(tx *bolt.Tx) error {
-		playerBytes := tx.Bucket([]byte("DB")).Get([]byte("PLAYER"))
-		var dbErr error = nil
-		if playerBytes != nil {
-			dbErr = json.Unmarshal(playerBytes, &player)
-		} else {
-			player = Player{0, 0, 0, 0, [6]float64{0}, false, false}
-		}
-		return dbErr
-	})
-	return player, err
+	record, err := store.LoadPlayer()
+	if err != nil {
+		return Player{}, err
+	}
+	return playerFromRecord(record), nil
 }
 
 func exitGracefully(err error) {
@@ -235,14 +476,21 @@ func manageCommands(player *Player) error {
 	playCommand := flag.NewFlagSet("play", flag.ExitOnError)
 	settingsCommand := flag.NewFlagSet("settings", flag.ExitOnError)
 	statsCommand := flag.NewFlagSet("stats", flag.ExitOnError)
+	dailyCommand := flag.NewFlagSet("daily", flag.ExitOnError)
+	hostCommand := flag.NewFlagSet("host", flag.ExitOnError)
+	joinCommand := flag.NewFlagSet("join", flag.ExitOnError)
 
 	// settings command flag pointers
 	settingsContrastPtr := settingsCommand.Bool("highContrast", player.HiContrast, "Turn high-contrast mode on/off")
 	settingsHardModePtr := settingsCommand.Bool("hardMode", player.HardMode, "Turn hard mode on/off")
+	settingsTUIPtr := settingsCommand.Bool("tui", player.UITUI, "Turn TUI mode on/off")
+
+	// host command flag pointers
+	hostPortPtr := hostCommand.Int("port", 8080, "Port to host the multiplayer server on")
 
 	// validate that correct number of arguments is being received
 	if len(os.Args) < 2 {
-		return fmt.Errorf("play, settings, or stats subcommand required")
+		return fmt.Errorf("play, settings, stats, daily, host, or join subcommand required")
 	}
 
 	switch os.Args[1] {
@@ -252,8 +500,14 @@ func manageCommands(player *Player) error {
 		settingsCommand.Parse(os.Args[2:])
 	case "stats":
 		statsCommand.Parse(os.Args[2:])
+	case "daily":
+		dailyCommand.Parse(os.Args[2:])
+	case "host":
+		hostCommand.Parse(os.Args[2:])
+	case "join":
+		joinCommand.Parse(os.Args[2:])
 	default:
-		return fmt.Errorf("play, settings, or stats subcommand required")
+		return fmt.Errorf("play, settings, stats, daily, host, or join subcommand required")
 	}
 
 	var err error
@@ -263,7 +517,26 @@ func manageCommands(player *Player) error {
 			return err
 		}
 	} else if settingsCommand.Parsed() {
-		err = player.ManageSettings(*settingsContrastPtr, *settingsHardModePtr)
+		err = player.ManageSettings(*settingsContrastPtr, *settingsHardModePtr, *settingsTUIPtr)
+		if err != nil {
+			return err
+		}
+	} else if dailyCommand.Parsed() {
+		err = player.PlayDaily()
+		if err != nil {
+			return err
+		}
+	} else if hostCommand.Parsed() {
+		err = HostServer(*hostPortPtr)
+		if err != nil {
+			return err
+		}
+	} else if joinCommand.Parsed() {
+		joinArgs := joinCommand.Args()
+		if len(joinArgs) != 2 {
+			return fmt.Errorf("join requires <host>:<port> <passphrase>")
+		}
+		err = player.JoinGame(joinArgs[0], joinArgs[1])
 		if err != nil {
 			return err
 		}
@@ -277,13 +550,11 @@ func manageCommands(player *Player) error {
 }
 
 func main() {
-	dbErr := setupDB()
-
-	if dbErr != nil {
-		exitGracefully(dbErr)
+	if err := setupStore(); err != nil {
+		exitGracefully(err)
 	}
 
-	defer db.Close()
+	defer store.Close()
 
 	// display usage info when user enters --help option
 	flag.Usage = func() {
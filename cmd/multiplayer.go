@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/j985chen/cli-ordle/ui"
+	"github.com/j985chen/cli-ordle/words"
+)
+
+const passphraseCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// mpLobby is a single head-to-head match, keyed by passphrase. Two players
+// race to solve the same answer; the server relays guesses and broadcasts
+// both boards back, masking the opponent's letters.
+type mpLobby struct {
+	mu     sync.Mutex
+	answer string
+	games  [2]*Game
+	conns  [2]*websocket.Conn
+	// writeMu serializes writes to conns[i]: both that slot's own handler
+	// (replying to a guess) and the other slot's handler (broadcasting a
+	// loss when it solves first) can write to the same connection, and
+	// gorilla/websocket allows at most one writer at a time per connection.
+	writeMu [2]sync.Mutex
+}
+
+// writeJSON sends v to the lobby's slot-th connection, serialized against
+// any other goroutine writing to that same connection.
+func (l *mpLobby) writeJSON(slot int, conn *websocket.Conn, v interface{}) error {
+	l.writeMu[slot].Lock()
+	defer l.writeMu[slot].Unlock()
+	return conn.WriteJSON(v)
+}
+
+var (
+	lobbiesMu sync.Mutex
+	lobbies   = map[string]*mpLobby{}
+)
+
+// mpClientMsg is sent from a join client to the host for each guess.
+type mpClientMsg struct {
+	Guess string `json:"guess"`
+}
+
+// mpServerMsg is broadcast from the host after every processed guess.
+type mpServerMsg struct {
+	Self     [][]ui.Cell `json:"self"`
+	Opponent [][]ui.Cell `json:"opponent"`
+	Won      bool        `json:"won"`
+	Lost     bool        `json:"lost"`
+	Error    string      `json:"error,omitempty"`
+}
+
+func generatePassphrase() string {
+	b := make([]byte, 6)
+	for i := range b {
+		b[i] = passphraseCharset[rand.Intn(len(passphraseCharset))]
+	}
+	return string(b)
+}
+
+// HostServer starts the multiplayer lobby server: it opens a lobby of its
+// own straight away and prints the passphrase, so a plain `cliordle host`
+// followed by `cliordle join` is enough to play, and POST /host remains
+// available for opening additional lobbies on the same server.
+func HostServer(port int) error {
+	passphrase, err := createLobby()
+	if err != nil {
+		return fmt.Errorf("could not create lobby: %v", err)
+	}
+
+	http.HandleFunc("/host", handleHost)
+	http.HandleFunc("/game/", handleJoinLobby)
+	addr := fmt.Sprintf(":%d", port)
+	fmt.Printf("hosting cliordle multiplayer on %s\n", addr)
+	fmt.Printf("lobby passphrase: %s\n", passphrase)
+	fmt.Printf("have your opponent run: cliordle join <this-host>:%d %s\n", port, passphrase)
+	return http.ListenAndServe(addr, nil)
+}
+
+// createLobby picks an answer and opens a new lobby under a random
+// passphrase, returning the passphrase so callers can hand it to a player.
+func createLobby() (string, error) {
+	answer, err := words.RandomWord()
+	if err != nil {
+		return "", err
+	}
+
+	passphrase := generatePassphrase()
+	lobbiesMu.Lock()
+	lobbies[passphrase] = &mpLobby{answer: answer}
+	lobbiesMu.Unlock()
+	return passphrase, nil
+}
+
+func handleHost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	passphrase, err := createLobby()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"passphrase": passphrase})
+}
+
+func handleJoinLobby(w http.ResponseWriter, r *http.Request) {
+	passphrase := strings.TrimPrefix(r.URL.Path, "/game/")
+
+	lobbiesMu.Lock()
+	lobby, ok := lobbies[passphrase]
+	lobbiesMu.Unlock()
+	if !ok {
+		http.Error(w, "no such lobby", http.StatusNotFound)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	lobby.mu.Lock()
+	slot := -1
+	for i, c := range lobby.conns {
+		if c == nil {
+			slot = i
+			break
+		}
+	}
+	if slot == -1 {
+		lobby.mu.Unlock()
+		conn.WriteJSON(mpServerMsg{Error: "lobby is full"})
+		conn.Close()
+		return
+	}
+	lobby.conns[slot] = conn
+	lobby.games[slot] = &Game{Player: &Player{}, WordsGuessed: []string{}, Answer: lobby.answer}
+	lobby.mu.Unlock()
+
+	defer func() {
+		lobby.mu.Lock()
+		lobby.conns[slot] = nil
+		lobby.games[slot] = nil
+		stillConnected := lobby.conns[0] != nil || lobby.conns[1] != nil
+		lobby.mu.Unlock()
+		conn.Close()
+		if !stillConnected {
+			lobbiesMu.Lock()
+			delete(lobbies, passphrase)
+			lobbiesMu.Unlock()
+		}
+	}()
+
+	for {
+		var msg mpClientMsg
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		lobby.mu.Lock()
+		game := lobby.games[slot]
+		guessErr := game.ProcessGuess(strings.ToLower(msg.Guess))
+		opponent := lobby.games[1-slot]
+		resp := mpServerMsg{Self: game.Rows(), Won: game.Solved}
+		opponentRows := emptyRows()
+		if opponent != nil {
+			opponentRows = opponent.Rows()
+		}
+		resp.Opponent = maskRows(opponentRows)
+		opponentConn := lobby.conns[1-slot]
+		lobby.mu.Unlock()
+
+		if guessErr != nil {
+			resp.Error = guessErr.Error()
+		}
+		lobby.writeJSON(slot, conn, resp)
+
+		if game.Solved && opponent != nil && opponentConn != nil {
+			lobby.writeJSON(1-slot, opponentConn, mpServerMsg{
+				Self:     opponentRows,
+				Opponent: maskRows(resp.Self),
+				Lost:     true,
+			})
+		}
+	}
+}
+
+// emptyRows is a blank 6x5 board, sent in place of an opponent's rows before
+// they've joined so the client never has to render a nil or short grid.
+func emptyRows() [][]ui.Cell {
+	rows := make([][]ui.Cell, 6)
+	for i := range rows {
+		rows[i] = make([]ui.Cell, 5)
+	}
+	return rows
+}
+
+// maskRows strips letters from rows, leaving only the green/yellow/absent
+// colours so an opponent's board can be shown without revealing its guesses.
+func maskRows(rows [][]ui.Cell) [][]ui.Cell {
+	masked := make([][]ui.Cell, len(rows))
+	for i, row := range rows {
+		maskedRow := make([]ui.Cell, len(row))
+		for j, cell := range row {
+			maskedRow[j] = ui.Cell{Status: cell.Status}
+		}
+		masked[i] = maskedRow
+	}
+	return masked
+}
+
+// JoinGame connects to a host started with `cliordle host` and plays a
+// head-to-head match: both boards are drawn side by side, with the
+// opponent's letters masked to colours only.
+func (p *Player) JoinGame(hostAddr string, passphrase string) error {
+	u := url.URL{Scheme: "ws", Host: hostAddr, Path: "/game/" + passphrase}
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %v", hostAddr, err)
+	}
+	defer conn.Close()
+
+	fmt.Printf("--- JOINED MULTIPLAYER LOBBY %s ---\n", passphrase)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Guess: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		guess := strings.ToLower(strings.TrimSuffix(input, "\n"))
+
+		if err := conn.WriteJSON(mpClientMsg{Guess: guess}); err != nil {
+			return err
+		}
+
+		var resp mpServerMsg
+		if err := conn.ReadJSON(&resp); err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			fmt.Printf("%s, try again\n", resp.Error)
+			continue
+		}
+
+		printSideBySide(resp.Self, resp.Opponent)
+
+		if resp.Won {
+			fmt.Println("You solved it first! 🎉")
+			p.MultiplayerWins++
+			return p.SaveStats()
+		}
+		if resp.Lost {
+			fmt.Println("Your opponent solved it first.")
+			return nil
+		}
+	}
+}
+
+func printSideBySide(self [][]ui.Cell, opponent [][]ui.Cell) {
+	placedColour, includesColour := colourGreen, colourYellow
+	fmt.Println(" YOU                        OPPONENT")
+	for i := 0; i < 6; i++ {
+		printRow(self[i], placedColour, includesColour, true)
+		fmt.Printf("   ")
+		printRow(opponent[i], placedColour, includesColour, false)
+		fmt.Println()
+	}
+}
+
+func printRow(row []ui.Cell, placedColour string, includesColour string, showLetters bool) {
+	for _, cell := range row {
+		fmt.Printf("|")
+		letter := cell.Letter
+		if !showLetters {
+			letter = " "
+		}
+		switch cell.Status {
+		case ui.StatusPlaced:
+			fmt.Printf(placedColour, letter)
+		case ui.StatusPresent:
+			fmt.Printf(includesColour, letter)
+		default:
+			fmt.Printf(" %s ", letter)
+		}
+		fmt.Printf("|")
+	}
+}
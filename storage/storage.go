@@ -0,0 +1,338 @@
+// Package storage is cliordle's persistence layer, backed by pebble
+// (github.com/cockroachdb/pebble) instead of the now-archived boltdb.
+//
+// PlayerRecord mirrors the JSON shape of cmd.Player's persisted fields
+// rather than importing package main, which isn't importable; cmd converts
+// between the two at the LoadPlayer/SavePlayer boundary. Internally it's
+// assembled from three keys — settings live on playerKey, running totals on
+// statsKey, and the streak on streakKey — so a hot per-game update never
+// has to Set the whole blob; see AppendGameResult and SaveStreak.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/boltdb/bolt"
+	"github.com/cockroachdb/pebble"
+)
+
+const (
+	playerKey = "PLAYER"
+	statsKey  = "STATS"
+	streakKey = "STREAK"
+)
+
+// PlayerRecord is the aggregated stats blob persisted for a player.
+type PlayerRecord struct {
+	Played          float64    `json:"played"`
+	Won             float64    `json:"won"`
+	CurrStreak      float64    `json:"currStreak"`
+	LongestStreak   float64    `json:"longestStreak"`
+	Distribution    [6]float64 `json:"stats"`
+	HiContrast      bool       `json:"hiContrast"`
+	HardMode        bool       `json:"hardMode"`
+	UITUI           bool       `json:"uiTUI"`
+	DailyStreak     float64    `json:"dailyStreak"`
+	LastDailyDay    int64      `json:"lastDailyDay"`
+	MultiplayerWins float64    `json:"multiplayerWins"`
+}
+
+// StatsRecord is the running-totals slice of PlayerRecord that lives under
+// statsKey, merged there via pebble's Merger. GameDelta is converted to this
+// same shape before every merge (see deltaToStats) so the value pebble hands
+// Merge is always a StatsRecord, whether it's the Set base or a Merge
+// operand (or, after compaction, several operands folded together) — one
+// schema for everything at this key, per pebble's merge contract.
+type StatsRecord struct {
+	Played       float64    `json:"played"`
+	Won          float64    `json:"won"`
+	Distribution [6]float64 `json:"stats"`
+}
+
+// StreakRecord is the streak slice of PlayerRecord that lives under
+// streakKey. It's kept out of the merger entirely: whether a win extends or
+// starts a streak depends on the order games are folded in, and pebble
+// doesn't promise MergeOlder/MergeNewer are applied in wall-clock order, so
+// streaks are updated with an ordinary LoadStreak/SaveStreak round trip.
+type StreakRecord struct {
+	CurrStreak    float64 `json:"currStreak"`
+	LongestStreak float64 `json:"longestStreak"`
+}
+
+// GameDelta is the small record describing a single finished practice game.
+// AppendGameResult merges it into statsKey via pebble's Merger, so two
+// cliordle processes finishing games at the same time (e.g. a daily and a
+// practice round) don't race on a read-modify-write of the whole record.
+type GameDelta struct {
+	Played          int `json:"played"`
+	Won             int `json:"won"`
+	DistributionIdx int `json:"distributionIdx"` // -1 on a loss
+}
+
+// Store is the persistence boundary cliordle plays against. LoadRaw/SaveRaw
+// cover the odds and ends (like the daily-puzzle record) that don't belong
+// in the merged PlayerRecord.
+type Store interface {
+	LoadPlayer() (PlayerRecord, error)
+	SavePlayer(PlayerRecord) error
+	AppendGameResult(GameDelta) error
+	LoadStreak() (StreakRecord, error)
+	SaveStreak(StreakRecord) error
+	LoadRaw(key string) ([]byte, bool, error)
+	SaveRaw(key string, value []byte) error
+	Close() error
+}
+
+type pebbleStore struct {
+	db *pebble.DB
+}
+
+// Open opens (creating if necessary) a pebble store at dir, wired up with
+// the stats Merger so AppendGameResult can do associative updates.
+func Open(dir string) (Store, error) {
+	db, err := pebble.Open(dir, &pebble.Options{Merger: statsMerger})
+	if err != nil {
+		return nil, fmt.Errorf("could not open pebble store at %s: %v", dir, err)
+	}
+	s := &pebbleStore{db: db}
+
+	// pebble hands the Merger the first operand as-is when statsKey has no
+	// Set base, so without a seeded record the first AppendGameResult a
+	// brand new player ever makes would seed the merger with its own delta.
+	// Seed a zero record (across all three keys) so Merge always sees a
+	// real base.
+	if _, found, err := s.LoadRaw(playerKey); err != nil {
+		return nil, err
+	} else if !found {
+		if err := s.SavePlayer(PlayerRecord{}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *pebbleStore) LoadPlayer() (PlayerRecord, error) {
+	raw, found, err := s.LoadRaw(playerKey)
+	if err != nil || !found {
+		return PlayerRecord{}, err
+	}
+	record, err := decodePlayerRecord(raw)
+	if err != nil {
+		return PlayerRecord{}, err
+	}
+
+	statsRaw, found, err := s.LoadRaw(statsKey)
+	if err != nil {
+		return PlayerRecord{}, err
+	}
+	if found {
+		stats, err := decodeStatsRecord(statsRaw)
+		if err != nil {
+			return PlayerRecord{}, err
+		}
+		record.Played, record.Won, record.Distribution = stats.Played, stats.Won, stats.Distribution
+	}
+
+	streak, err := s.LoadStreak()
+	if err != nil {
+		return PlayerRecord{}, err
+	}
+	record.CurrStreak, record.LongestStreak = streak.CurrStreak, streak.LongestStreak
+	return record, nil
+}
+
+// SavePlayer overwrites all three of a player's keys at once. It's meant for
+// infrequent full-state writes (settings changes, the one-shot bolt
+// migration) — the hot per-game path uses AppendGameResult and SaveStreak
+// instead, so it never has to Set (and thereby clobber) the whole record.
+func (s *pebbleStore) SavePlayer(record PlayerRecord) error {
+	statsRaw, err := json.Marshal(StatsRecord{Played: record.Played, Won: record.Won, Distribution: record.Distribution})
+	if err != nil {
+		return fmt.Errorf("could not marshal stats record: %v", err)
+	}
+	if err := s.SaveRaw(statsKey, statsRaw); err != nil {
+		return err
+	}
+	if err := s.SaveStreak(StreakRecord{CurrStreak: record.CurrStreak, LongestStreak: record.LongestStreak}); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not marshal player record: %v", err)
+	}
+	return s.SaveRaw(playerKey, raw)
+}
+
+func (s *pebbleStore) AppendGameResult(delta GameDelta) error {
+	raw, err := json.Marshal(deltaToStats(delta))
+	if err != nil {
+		return fmt.Errorf("could not marshal game delta: %v", err)
+	}
+	return s.db.Merge([]byte(statsKey), raw, pebble.Sync)
+}
+
+func (s *pebbleStore) LoadStreak() (StreakRecord, error) {
+	raw, found, err := s.LoadRaw(streakKey)
+	if err != nil || !found {
+		return StreakRecord{}, err
+	}
+	var streak StreakRecord
+	if err := json.Unmarshal(raw, &streak); err != nil {
+		return StreakRecord{}, fmt.Errorf("could not unmarshal streak record: %v", err)
+	}
+	return streak, nil
+}
+
+func (s *pebbleStore) SaveStreak(streak StreakRecord) error {
+	raw, err := json.Marshal(streak)
+	if err != nil {
+		return fmt.Errorf("could not marshal streak record: %v", err)
+	}
+	return s.SaveRaw(streakKey, raw)
+}
+
+func (s *pebbleStore) LoadRaw(key string) ([]byte, bool, error) {
+	value, closer, err := s.db.Get([]byte(key))
+	if err == pebble.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not load %s: %v", key, err)
+	}
+	defer closer.Close()
+	raw := make([]byte, len(value))
+	copy(raw, value)
+	return raw, true, nil
+}
+
+func (s *pebbleStore) SaveRaw(key string, value []byte) error {
+	if err := s.db.Set([]byte(key), value, pebble.Sync); err != nil {
+		return fmt.Errorf("could not set %s: %v", key, err)
+	}
+	return nil
+}
+
+func (s *pebbleStore) Close() error {
+	return s.db.Close()
+}
+
+func decodePlayerRecord(raw []byte) (PlayerRecord, error) {
+	var record PlayerRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return PlayerRecord{}, fmt.Errorf("could not unmarshal player record: %v", err)
+	}
+	return record, nil
+}
+
+func decodeStatsRecord(raw []byte) (StatsRecord, error) {
+	var stats StatsRecord
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		return StatsRecord{}, fmt.Errorf("could not unmarshal stats record: %v", err)
+	}
+	return stats, nil
+}
+
+// deltaToStats re-encodes a GameDelta's shorthand (a single distributionIdx)
+// as a StatsRecord, the canonical schema statsKey is always stored in.
+func deltaToStats(delta GameDelta) StatsRecord {
+	var stats StatsRecord
+	stats.Played = float64(delta.Played)
+	stats.Won = float64(delta.Won)
+	if delta.Won > 0 && delta.DistributionIdx >= 0 && delta.DistributionIdx < len(stats.Distribution) {
+		stats.Distribution[delta.DistributionIdx] = 1
+	}
+	return stats
+}
+
+// addStats folds b into a. Every field is a running total or a per-index
+// counter, so addition is commutative and associative — folding operands in
+// any order, which is all pebble promises, produces the same result.
+func addStats(a *StatsRecord, b StatsRecord) {
+	a.Played += b.Played
+	a.Won += b.Won
+	for i := range a.Distribution {
+		a.Distribution[i] += b.Distribution[i]
+	}
+}
+
+// statsMerger folds StatsRecord operands into the aggregate stored under
+// statsKey. Every value ever written there — the Set base and every Merge
+// operand, including ones pebble has already folded together during
+// compaction — uses the exact same StatsRecord encoding, so Merge never has
+// to guess which shape `value` is.
+var statsMerger = &pebble.Merger{
+	Name: "cliordle.StatsRecord",
+	Merge: func(key, value []byte) (pebble.ValueMerger, error) {
+		stats, err := decodeStatsRecord(value)
+		if err != nil {
+			return nil, err
+		}
+		return &statsValueMerger{stats: stats}, nil
+	},
+}
+
+type statsValueMerger struct {
+	stats StatsRecord
+}
+
+func (m *statsValueMerger) MergeNewer(value []byte) error {
+	delta, err := decodeStatsRecord(value)
+	if err != nil {
+		return err
+	}
+	addStats(&m.stats, delta)
+	return nil
+}
+
+// MergeOlder folds an operand pebble determined came before the ones already
+// merged. addStats is commutative, so folding order doesn't matter and this
+// can delegate straight to MergeNewer.
+func (m *statsValueMerger) MergeOlder(value []byte) error {
+	return m.MergeNewer(value)
+}
+
+func (m *statsValueMerger) Finish(includesBase bool) ([]byte, error) {
+	return json.Marshal(m.stats)
+}
+
+// Migrate copies the PLAYER record out of a legacy bolt database (if one
+// exists at boltPath and store doesn't already have data) into store. It's a
+// one-shot: once store has a player record, Migrate is a no-op on every
+// later run.
+func Migrate(boltPath string, store Store) error {
+	if _, err := os.Stat(boltPath); err != nil {
+		return nil
+	}
+	if existing, err := store.LoadPlayer(); err != nil {
+		return err
+	} else if existing != (PlayerRecord{}) {
+		return nil
+	}
+
+	boltDB, err := bolt.Open(boltPath, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("could not open legacy bolt db at %s: %v", boltPath, err)
+	}
+	defer boltDB.Close()
+
+	var record PlayerRecord
+	err = boltDB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte("DB"))
+		if bucket == nil {
+			return nil
+		}
+		raw := bucket.Get([]byte(playerKey))
+		if raw == nil {
+			return nil
+		}
+		return json.Unmarshal(raw, &record)
+	})
+	if err != nil {
+		return fmt.Errorf("could not read legacy player record: %v", err)
+	}
+
+	return store.SavePlayer(record)
+}
@@ -0,0 +1,139 @@
+// Package ui implements a bubbletea front-end for cliordle, used instead of
+// the bufio.Reader input loop in Game.PlayGame when a player turns on TUI
+// mode (`cliordle settings --tui`).
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CellStatus is the evaluation state of a single guessed letter.
+type CellStatus int
+
+const (
+	StatusEmpty CellStatus = iota
+	StatusAbsent
+	StatusPresent
+	StatusPlaced
+)
+
+// Cell is one letter tile on the board, already evaluated against the answer.
+type Cell struct {
+	Letter string
+	Status CellStatus
+}
+
+// GuessGame is the subset of Game's behaviour the TUI needs. It's expressed
+// as an interface (rather than importing package main, which isn't
+// importable) so both the TUI and the legacy stdin printer can share the
+// same board-rendering data.
+type GuessGame interface {
+	ProcessGuess(guess string) error
+	Rows() [][]Cell
+	IsSolved() bool
+	GuessesUsed() int
+	MaxGuesses() int
+	Word() string
+	// ColourScheme returns the printf-style colour formats to use for a
+	// placed (green/orange) and an included (yellow/blue) letter, honouring
+	// the player's high-contrast setting.
+	ColourScheme() (placed string, included string)
+}
+
+// Model is the bubbletea Model/Update/View implementation driving a Game.
+type Model struct {
+	game    GuessGame
+	input   string
+	err     error
+	aborted bool
+}
+
+// New builds a Model wrapping an in-progress game.
+func New(g GuessGame) Model {
+	return Model{game: g}
+}
+
+// Aborted reports whether the player quit (Ctrl-C/Esc) before the game
+// reached a natural end, so callers can tell that apart from a solve or a
+// run of six guesses and skip recording a result for it.
+func (m Model) Aborted() bool {
+	return m.aborted
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.aborted = true
+		return m, tea.Quit
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeyEnter:
+		if len(m.input) != 5 {
+			break
+		}
+		if err := m.game.ProcessGuess(m.input); err != nil {
+			m.err = err
+		} else {
+			m.err = nil
+			m.input = ""
+		}
+	case tea.KeyRunes:
+		for _, r := range keyMsg.Runes {
+			if len(m.input) < 5 && r >= 'a' && r <= 'z' {
+				m.input += string(r)
+			} else if len(m.input) < 5 && r >= 'A' && r <= 'Z' {
+				m.input += strings.ToLower(string(r))
+			}
+		}
+	}
+
+	if m.game.IsSolved() || m.game.GuessesUsed() >= m.game.MaxGuesses() {
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	placedColour, includesColour := m.game.ColourScheme()
+	rows := m.game.Rows()
+
+	var b strings.Builder
+	b.WriteString(" ___  ___  ___  ___  ___\n")
+	for _, row := range rows {
+		for _, cell := range row {
+			b.WriteString("|")
+			switch cell.Status {
+			case StatusPlaced:
+				b.WriteString(fmt.Sprintf(placedColour, cell.Letter))
+			case StatusPresent:
+				b.WriteString(fmt.Sprintf(includesColour, cell.Letter))
+			case StatusAbsent:
+				b.WriteString(fmt.Sprintf(" %s ", cell.Letter))
+			default:
+				b.WriteString("   ")
+			}
+			b.WriteString("|")
+		}
+		b.WriteString("\n ---  ---  ---  ---  ---\n")
+	}
+
+	b.WriteString(fmt.Sprintf("\nGuess %d/%d: %s\n", m.game.GuessesUsed()+1, m.game.MaxGuesses(), strings.ToUpper(m.input)))
+	if m.err != nil {
+		b.WriteString(fmt.Sprintf("%v, try again\n", m.err))
+	}
+	return b.String()
+}